@@ -11,7 +11,7 @@ from typing import Any
 
 from src.knowledge import get_neo4j_client
 from src.knowledge.queries import COMPETITION_COLLAPSE_QUERY, FIND_AFFECTED_COMBOS_QUERY
-from src.llms import get_llm
+from src.llms import get_agent_params, get_llm
 from src.utils import get_logger
 
 logger = get_logger(__name__)
@@ -219,6 +219,7 @@ class CompetitionAnalyzer:
         response = await llm.generate(
             prompt=prompt,
             system_prompt="你是一位资深的创新药投资分析师，专注于竞争格局分析和风险评估。",
+            **get_agent_params("competition"),
         )
         
         # 提取建议
@@ -11,7 +11,7 @@ from typing import Any
 
 from src.knowledge import get_neo4j_client
 from src.knowledge.queries import DATA_INTEGRITY_CHECK_QUERY, SUSPICIOUS_DATA_QUERY
-from src.llms import get_llm
+from src.llms import get_agent_params, get_llm
 from src.utils import get_logger
 
 logger = get_logger(__name__)
@@ -257,6 +257,7 @@ class DataIntegrityChecker:
         response = await llm.generate(
             prompt=prompt,
             system_prompt="你是一位临床数据审计专家，专注于识别临床试验数据中的潜在问题和统计学陷阱。",
+            **get_agent_params("integrity"),
         )
         
         # 提取建议
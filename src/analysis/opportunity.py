@@ -10,7 +10,7 @@ from typing import Any
 
 from src.knowledge import get_neo4j_client
 from src.knowledge.queries import OPPORTUNITY_DISCOVERY_QUERY, HIGH_UNMET_NEED_QUERY
-from src.llms import get_llm
+from src.llms import get_agent_params, get_llm
 from src.utils import get_logger
 
 logger = get_logger(__name__)
@@ -236,6 +236,7 @@ class OpportunityAnalyzer:
         response = await llm.generate(
             prompt=prompt,
             system_prompt="你是一位资深的创新药投资分析师，专注于发现未被满足的医疗需求和投资机会。",
+            **get_agent_params("opportunity"),
         )
         
         # 提取建议
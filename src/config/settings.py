@@ -12,7 +12,7 @@ from pathlib import Path
 from typing import Any, Literal
 
 import yaml
-from pydantic import Field
+from pydantic import BaseModel, Field
 from pydantic_settings import BaseSettings
 
 
@@ -43,6 +43,17 @@ class LLMConfig(BaseSettings):
     temperature: float = 0.7
     max_tokens: int = 4096
     max_retries: int = 3
+    retry_max_backoff: float = 10.0  # 重试退避的上限 (秒)
+    max_retry_after: float = 60.0  # 遵循服务端 Retry-After 时的最长等待 (秒)
+    # structured_output 请求的响应格式，模型拒绝该参数时本次请求退回 text
+    response_format: Literal["text", "json_object", "json_schema"] = "json_object"
+
+
+class AgentLLMConfig(BaseModel):
+    """Agent 级别的生成参数，未设置的字段使用模型默认值"""
+    temperature: float | None = None
+    max_tokens: int | None = None
+    top_p: float | None = None
 
 
 class Neo4jConfig(BaseSettings):
@@ -66,6 +77,8 @@ class VectorDBConfig(BaseSettings):
     type: Literal["chroma", "milvus"] = "chroma"
     persist_directory: str = "./data/chroma"
     collection_name: str = "biovalue_docs"
+    dimension: int = 1536  # 需与嵌入模型输出维度一致
+    metric: Literal["cosine", "l2", "ip"] = "cosine"
 
 
 class APIConfig(BaseSettings):
@@ -112,6 +125,9 @@ class Settings(BaseSettings):
     extraction_model: LLMConfig = Field(default_factory=LLMConfig)
     embedding_model: LLMConfig = Field(default_factory=LLMConfig)
     
+    # Agent 生成参数
+    agents: dict[str, AgentLLMConfig] = Field(default_factory=dict)
+    
     # 数据库配置
     neo4j: Neo4jConfig = Field(default_factory=Neo4jConfig)
     redis: RedisConfig = Field(default_factory=RedisConfig)
@@ -149,6 +165,12 @@ class Settings(BaseSettings):
             if yaml_key in config:
                 settings_dict[settings_key] = LLMConfig(**config[yaml_key])
         
+        if "AGENTS" in config:
+            settings_dict["agents"] = {
+                name: AgentLLMConfig(**(params or {}))
+                for name, params in config["AGENTS"].items()
+            }
+        
         # 其他配置
         if "NEO4J" in config:
             settings_dict["neo4j"] = Neo4jConfig(**config["NEO4J"])
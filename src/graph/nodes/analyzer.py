@@ -16,7 +16,7 @@ from src.knowledge.queries import (
     DRUG_FULL_PROFILE_QUERY,
     INDICATION_LANDSCAPE_QUERY,
 )
-from src.llms import get_llm
+from src.llms import get_agent_params, get_llm
 from src.utils import get_logger
 
 from ..state import (
@@ -170,6 +170,7 @@ async def _analyze_competition(
     response = await llm.generate(
         prompt=analysis_prompt,
         system_prompt=ANALYZER_SYSTEM_PROMPT,
+        **get_agent_params("analyzer"),
     )
     
     return AnalysisResult(
@@ -221,6 +222,7 @@ async def _find_opportunity(
     response = await llm.generate(
         prompt=analysis_prompt,
         system_prompt=ANALYZER_SYSTEM_PROMPT,
+        **get_agent_params("analyzer"),
     )
     
     return AnalysisResult(
@@ -270,6 +272,7 @@ async def _check_integrity(
     response = await llm.generate(
         prompt=analysis_prompt,
         system_prompt=ANALYZER_SYSTEM_PROMPT,
+        **get_agent_params("analyzer"),
     )
     
     return AnalysisResult(
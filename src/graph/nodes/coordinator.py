@@ -5,7 +5,7 @@
 
 from langchain_core.messages import AIMessage, HumanMessage
 
-from src.llms import get_llm
+from src.llms import get_agent_params, get_llm
 from src.utils import get_logger
 
 from ..state import (
@@ -91,6 +91,7 @@ async def coordinator_node(state: WorkflowState) -> dict:
             prompt=f"请分析以下情况并决定下一步行动:\n{context}",
             schema=CoordinatorDecision,
             system_prompt=COORDINATOR_SYSTEM_PROMPT,
+            **get_agent_params("coordinator"),
         )
         
         logger.info(f"Coordinator decision: {decision.next_action} - {decision.reasoning}")
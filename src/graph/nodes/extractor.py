@@ -7,9 +7,12 @@
 - 外部 API 数据获取
 """
 
+from typing import Any
+
 from langchain_core.messages import AIMessage
 
-from src.llms import get_llm
+from src.llms import get_agent_params, get_llm
+from src.llms.base import LLMResponseError, extract_json
 from src.knowledge.models import (
     Company, Drug, Indication, Trial, EndpointData
 )
@@ -85,6 +88,13 @@ ENTITY_SCHEMAS = {
 }
 
 
+def _is_entity_data(data: Any) -> bool:
+    """提取结果应为实体对象或实体对象数组"""
+    if isinstance(data, dict):
+        return True
+    return isinstance(data, list) and all(isinstance(item, dict) for item in data)
+
+
 async def extractor_node(state: WorkflowState) -> dict:
     """数据提取节点
     
@@ -149,21 +159,12 @@ async def extractor_node(state: WorkflowState) -> dict:
             response = await llm.generate(
                 prompt=extraction_prompt,
                 system_prompt=EXTRACTOR_SYSTEM_PROMPT,
-                temperature=0,
+                **get_agent_params("extractor", temperature=0),
             )
             
             # 解析响应
-            import json
-            content = response.content
-            
-            # 提取 JSON 部分
-            if "```json" in content:
-                content = content.split("```json")[1].split("```")[0]
-            elif "```" in content:
-                content = content.split("```")[1].split("```")[0]
-            
             try:
-                entities_data = json.loads(content.strip())
+                entities_data = extract_json(response.content, accept=_is_entity_data)
                 if not isinstance(entities_data, list):
                     entities_data = [entities_data]
                 
@@ -178,7 +179,7 @@ async def extractor_node(state: WorkflowState) -> dict:
                         )
                     )
                     
-            except json.JSONDecodeError:
+            except LLMResponseError:
                 logger.warning(f"Failed to parse extraction result for {entity_type}")
                 
         except Exception as e:
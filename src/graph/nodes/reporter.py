@@ -5,7 +5,7 @@
 
 from langchain_core.messages import AIMessage
 
-from src.llms import get_llm
+from src.llms import get_agent_params, get_llm
 from src.utils import get_logger
 
 from ..state import (
@@ -114,13 +114,17 @@ async def reporter_node(state: WorkflowState) -> dict:
         response = await llm.generate(
             prompt=report_prompt,
             system_prompt=REPORTER_SYSTEM_PROMPT,
+            **get_agent_params("reporter"),
         )
         
         final_report = response.content
         
         # 生成简短摘要
         summary_prompt = f"请用一句话总结以下报告的核心结论:\n\n{final_report[:1000]}"
-        summary_response = await llm.generate(prompt=summary_prompt)
+        summary_response = await llm.generate(
+            prompt=summary_prompt,
+            **get_agent_params("reporter"),
+        )
         summary = summary_response.content
         
     except Exception as e:
@@ -4,6 +4,7 @@
 - 节点模型 (Company, Drug, Indication, Trial, Data, Asset, External)
 - 边模型 (TREATS, OUTPUTS, COMBINED_WITH, HAS_SOC)
 - Neo4j 客户端封装
+- 向量数据库客户端
 """
 
 from .models.nodes import (
@@ -24,6 +25,7 @@ from .models.edges import (
     HasSocRelation,
 )
 from .neo4j_client import Neo4jClient, get_neo4j_client
+from .vector_store import VectorMatch, VectorStore, get_vector_store
 
 __all__ = [
     # Nodes
@@ -44,5 +46,8 @@ __all__ = [
     # Client
     "Neo4jClient",
     "get_neo4j_client",
+    "VectorStore",
+    "VectorMatch",
+    "get_vector_store",
 ]
 
@@ -0,0 +1,213 @@
+# 向量数据库客户端封装
+"""
+向量数据库客户端，用于存储和检索分析上下文:
+- 向量写入 (附带元数据)
+- 相似度检索 (RAG 上下文召回)
+"""
+
+import asyncio
+import threading
+from abc import ABC, abstractmethod
+from typing import Any
+
+from pydantic import BaseModel, Field
+
+from src.config import get_settings
+from src.utils import get_logger
+
+logger = get_logger(__name__)
+
+# 全局客户端实例
+_vector_store: "VectorStore | None" = None
+
+
+class VectorDimensionError(ValueError):
+    """向量维度与配置不一致"""
+    pass
+
+
+class VectorPayloadError(ValueError):
+    """元数据包含后端不支持的值"""
+    pass
+
+
+class VectorMatch(BaseModel):
+    """向量检索结果"""
+    id: str
+    score: float = Field(..., description="相似度得分，越大越相似")
+    payload: dict[str, Any] = Field(default_factory=dict)
+
+
+class VectorStore(ABC):
+    """向量数据库抽象基类
+    
+    所有向量数据库实现必须继承此基类，便于在 Chroma/Milvus 等后端间切换。
+    """
+    
+    def __init__(self, dimension: int):
+        self.dimension = dimension
+    
+    def _check_dimension(self, vector: list[float]) -> None:
+        """校验向量维度"""
+        if len(vector) != self.dimension:
+            raise VectorDimensionError(
+                f"Expected vector dimension {self.dimension}, got {len(vector)}"
+            )
+    
+    @abstractmethod
+    async def store(
+        self,
+        id: str,
+        vector: list[float],
+        payload: dict[str, Any] | None = None,
+    ) -> None:
+        """写入向量，相同 id 会覆盖已有记录
+        
+        Args:
+            id: 记录ID
+            vector: 嵌入向量
+            payload: 元数据(来源、文本片段等)
+        """
+        ...
+    
+    @abstractmethod
+    async def search(
+        self,
+        vector: list[float],
+        top_k: int = 5,
+    ) -> list[VectorMatch]:
+        """相似度检索
+        
+        Args:
+            vector: 查询向量
+            top_k: 返回结果数量
+        
+        Returns:
+            list[VectorMatch]: 按相似度降序排列的结果
+        """
+        ...
+
+
+class ChromaVectorStore(VectorStore):
+    """ChromaDB 向量存储实现
+    
+    Chroma 元数据只支持 str/int/float/bool 标量值，嵌套结构需调用方先序列化。
+    """
+    
+    PAYLOAD_TYPES = (str, int, float, bool)
+    
+    def __init__(
+        self,
+        persist_directory: str,
+        collection_name: str,
+        dimension: int,
+        metric: str = "cosine",
+    ):
+        super().__init__(dimension)
+        self.persist_directory = persist_directory
+        self.collection_name = collection_name
+        self.metric = metric
+        self._collection: Any = None
+        self._lock = threading.Lock()
+    
+    def _get_collection(self) -> Any:
+        """打开 Chroma 集合 (涉及磁盘 I/O，只在工作线程中调用)"""
+        with self._lock:
+            if self._collection is None:
+                import chromadb
+                
+                logger.info(f"Opening Chroma collection {self.collection_name} at {self.persist_directory}")
+                client = chromadb.PersistentClient(path=self.persist_directory)
+                self._collection = client.get_or_create_collection(
+                    name=self.collection_name,
+                    metadata={"hnsw:space": self.metric},
+                )
+        return self._collection
+    
+    def _check_payload(self, payload: dict[str, Any] | None) -> None:
+        """校验元数据只包含 Chroma 支持的标量值"""
+        for key, value in (payload or {}).items():
+            if not isinstance(value, self.PAYLOAD_TYPES):
+                raise VectorPayloadError(
+                    f"Payload field {key!r} has unsupported type {type(value).__name__}; "
+                    "Chroma metadata values must be str, int, float or bool"
+                )
+    
+    def _upsert(self, id: str, vector: list[float], payload: dict[str, Any] | None) -> None:
+        self._get_collection().upsert(
+            ids=[id],
+            embeddings=[vector],
+            metadatas=[payload] if payload else None,
+        )
+    
+    def _query(self, vector: list[float], top_k: int) -> dict[str, Any] | None:
+        collection = self._get_collection()
+        count = collection.count()
+        if count == 0:
+            # 不同 chromadb 版本对空集合查询的行为不一致 (报错或返回空)，统一返回空
+            return None
+        return collection.query(
+            query_embeddings=[vector],
+            n_results=min(top_k, count),
+        )
+    
+    def _to_score(self, distance: float) -> float:
+        """将 Chroma 距离转换为相似度得分"""
+        if self.metric == "l2":
+            return 1.0 / (1.0 + distance)
+        # cosine / ip 距离均为 1 - 相似度
+        return 1.0 - distance
+    
+    async def store(
+        self,
+        id: str,
+        vector: list[float],
+        payload: dict[str, Any] | None = None,
+    ) -> None:
+        """写入向量"""
+        self._check_dimension(vector)
+        self._check_payload(payload)
+        
+        await asyncio.to_thread(self._upsert, id, vector, payload)
+    
+    async def search(
+        self,
+        vector: list[float],
+        top_k: int = 5,
+    ) -> list[VectorMatch]:
+        """相似度检索"""
+        self._check_dimension(vector)
+        
+        result = await asyncio.to_thread(self._query, vector, top_k)
+        if result is None:
+            return []
+        
+        ids = result["ids"][0]
+        distances = result["distances"][0]
+        metadatas = result["metadatas"][0]
+        
+        return [
+            VectorMatch(id=match_id, score=self._to_score(distance), payload=metadata or {})
+            for match_id, distance, metadata in zip(ids, distances, metadatas)
+        ]
+
+
+def get_vector_store() -> VectorStore:
+    """获取向量数据库客户端单例"""
+    global _vector_store
+    
+    if _vector_store is None:
+        settings = get_settings()
+        config = settings.vector_db
+        
+        if config.type != "chroma":
+            raise NotImplementedError(f"Vector DB type not supported yet: {config.type}")
+        
+        _vector_store = ChromaVectorStore(
+            persist_directory=config.persist_directory,
+            collection_name=config.collection_name,
+            dimension=config.dimension,
+            metric=config.metric,
+        )
+    
+    return _vector_store
@@ -3,10 +3,21 @@
 定义 LLM 的统一抽象接口，实现解耦设计
 """
 
+import json
+import random
+import re
 from abc import ABC, abstractmethod
-from typing import Any, AsyncIterator, Literal, TypeVar
+from datetime import datetime, timezone
+from email.utils import parsedate_to_datetime
+from typing import Any, AsyncIterator, Callable, Iterator, Literal, TypeVar
 
-from pydantic import BaseModel
+from pydantic import BaseModel, ValidationError
+from tenacity import RetryCallState
+from tenacity.wait import wait_base
+
+from src.utils import get_logger
+
+logger = get_logger(__name__)
 
 # LLM 类型定义
 LLMType = Literal["reasoning", "basic", "extraction", "embedding"]
@@ -14,6 +25,9 @@ LLMType = Literal["reasoning", "basic", "extraction", "embedding"]
 # 泛型类型变量，用于结构化输出
 T = TypeVar("T", bound=BaseModel)
 
+# 结构化输出的响应格式
+ResponseFormat = Literal["text", "json_object", "json_schema"]
+
 
 class LLMResponse(BaseModel):
     """LLM 响应模型"""
@@ -37,6 +51,9 @@ class BaseLLM(ABC):
         base_url: str | None = None,
         temperature: float = 0.7,
         max_tokens: int = 4096,
+        response_format: ResponseFormat = "json_object",
+        retry_max_backoff: float = 10.0,
+        max_retry_after: float = 60.0,
         **kwargs
     ):
         self.model = model
@@ -44,8 +61,91 @@ class BaseLLM(ABC):
         self.base_url = base_url
         self.temperature = temperature
         self.max_tokens = max_tokens
+        self.response_format = response_format
+        self.retry_max_backoff = retry_max_backoff
+        self.max_retry_after = max_retry_after
         self.extra_kwargs = kwargs
     
+    # 可选请求参数，仅在调用方显式传入时透传给提供商
+    OPTIONAL_PARAMS = ("top_p", "response_format")
+    
+    # 错误内容中指向 JSON 模式参数的片段，用于识别模型不支持该参数的 400
+    RESPONSE_FORMAT_MARKER = "response_format"
+    
+    def _optional_params(self, kwargs: dict[str, Any]) -> dict[str, Any]:
+        """提取调用方显式传入的可选请求参数"""
+        return {key: kwargs[key] for key in self.OPTIONAL_PARAMS if key in kwargs}
+    
+    async def _generate_json(
+        self,
+        prompt: str,
+        schema: type[BaseModel],
+        system_prompt: str | None = None,
+        **kwargs
+    ) -> LLMResponse:
+        """以 JSON 模式生成响应
+        
+        仅当 HTTP 400 的错误内容指向 response_format 参数时，本次调用以普通模式
+        重新请求；实例配置不变，其他请求错误直接抛出。
+        """
+        response_format = build_response_format(self.response_format, schema)
+        if response_format is None:
+            return await self.generate(prompt=prompt, system_prompt=system_prompt, **kwargs)
+        
+        try:
+            return await self.generate(
+                prompt=prompt,
+                system_prompt=system_prompt,
+                response_format=response_format,
+                **kwargs
+            )
+        except LLMResponseError as e:
+            if e.status_code != 400 or self.RESPONSE_FORMAT_MARKER not in error_detail(e.body):
+                raise
+            logger.warning(f"Model {self.model} rejected {self.response_format}, falling back to text: {e}")
+            return await self.generate(prompt=prompt, system_prompt=system_prompt, **kwargs)
+    
+    def _clean_structured_content(self, content: str) -> str:
+        """结构化解析前预处理响应内容，子类可覆盖"""
+        return content
+    
+    async def _generate_structured(
+        self,
+        prompt: str,
+        schema: type[T],
+        system_prompt: str,
+        **kwargs
+    ) -> T:
+        """生成并解析结构化输出
+        
+        默认使用低温度；解析或校验失败时附带错误信息以纠正提示重试一次。
+        
+        Args:
+            prompt: 用户提示词
+            schema: Pydantic 模型类
+            system_prompt: 已包含 schema 说明的系统提示词
+            **kwargs: 额外参数
+        """
+        kwargs.setdefault("temperature", 0)
+        
+        response = await self._generate_json(
+            prompt=prompt,
+            schema=schema,
+            system_prompt=system_prompt,
+            **kwargs
+        )
+        
+        try:
+            return parse_structured(self._clean_structured_content(response.content), schema)
+        except LLMResponseError as e:
+            response = await self._generate_json(
+                prompt=f"{prompt}\n\n{STRUCTURED_RETRY_PROMPT.format(error=e)}",
+                schema=schema,
+                system_prompt=system_prompt,
+                **kwargs
+            )
+            return parse_structured(self._clean_structured_content(response.content), schema)
+    
     @abstractmethod
     async def generate(
         self,
@@ -134,11 +234,240 @@ class LLMConnectionError(LLMError):
 
 
 class LLMRateLimitError(LLMError):
-    """速率限制错误"""
-    pass
+    """速率限制错误
+    
+    retry_after 为服务端通过 Retry-After 头建议的等待秒数
+    """
+    
+    def __init__(self, message: str = "Rate limit exceeded", retry_after: float | None = None):
+        super().__init__(message)
+        self.retry_after = retry_after
 
 
 class LLMResponseError(LLMError):
-    """响应解析错误"""
-    pass
+    """响应解析错误
+    
+    status_code / body 为 HTTP 错误时的响应状态码与响应内容
+    """
+    
+    def __init__(self, message: str, status_code: int | None = None, body: str | None = None):
+        super().__init__(message)
+        self.status_code = status_code
+        self.body = body
+
+
+def error_detail(body: str | None) -> str:
+    """从 HTTP 错误响应体中取出错误信息
+    
+    兼容 {"error": "..."} 与 {"error": {"message": ..., "param": ...}} 两种格式，
+    无法解析为 JSON 时返回原文。
+    """
+    if not body:
+        return ""
+    try:
+        data = json.loads(body)
+    except json.JSONDecodeError:
+        return body
+    
+    error = data.get("error", data) if isinstance(data, dict) else data
+    if isinstance(error, dict):
+        return " ".join(str(error[key]) for key in ("param", "message") if error.get(key))
+    return str(error)
 
+
+def is_retryable_error(exc: BaseException) -> bool:
+    """判断 generate 失败后是否值得重试
+    
+    取消等非 Exception 异常立即抛出；4xx 客户端错误 (429 除外) 重试不会改变结果，
+    同样直接抛出。
+    """
+    if not isinstance(exc, Exception):
+        return False
+    if isinstance(exc, LLMResponseError) and exc.status_code is not None:
+        return exc.status_code == 429 or not 400 <= exc.status_code < 500
+    return True
+
+
+def parse_retry_after(value: str | None) -> float | None:
+    """解析 Retry-After 响应头
+    
+    支持秒数和 HTTP 日期两种格式，无法解析时返回 None。
+    """
+    if not value:
+        return None
+    
+    try:
+        return max(float(value), 0.0)
+    except ValueError:
+        pass
+    
+    try:
+        retry_at = parsedate_to_datetime(value)
+    except (TypeError, ValueError):
+        return None
+    
+    if retry_at.tzinfo is None:
+        retry_at = retry_at.replace(tzinfo=timezone.utc)
+    return max((retry_at - datetime.now(timezone.utc)).total_seconds(), 0.0)
+
+
+class wait_retry_after(wait_base):
+    """优先遵循服务端 Retry-After 的 tenacity 等待策略
+    
+    上一次失败为带 retry_after 的 LLMRateLimitError 时等待服务端指定的时长，
+    否则退回到 fallback 策略。上限优先取被装饰方法所属实例的 max_retry_after，
+    否则使用 max_wait。服务端给出的时长是明确的恢复时间，因此上限单独配置，
+    通常大于盲目退避的 retry_max_backoff。
+    """
+    
+    def __init__(self, fallback: wait_base, max_wait: float = 60.0):
+        self.fallback = fallback
+        self.max_wait = max_wait
+    
+    def __call__(self, retry_state: RetryCallState) -> float:
+        outcome = retry_state.outcome
+        exc = outcome.exception() if outcome is not None else None
+        if isinstance(exc, LLMRateLimitError) and exc.retry_after is not None:
+            cap = self.max_wait
+            if retry_state.args:
+                cap = getattr(retry_state.args[0], "max_retry_after", cap)
+            return min(exc.retry_after, cap)
+        return self.fallback(retry_state)
+
+
+class wait_full_jitter(wait_base):
+    """全抖动 (full jitter) 指数退避
+    
+    第 n 次重试在 [0, min(cap, multiplier * 2^(n-1))] 内随机等待，避免大量请求
+    同时触发限流后在同一时刻重试。cap 优先取被装饰方法所属实例的
+    retry_max_backoff，否则使用 max_wait。
+    """
+    
+    def __init__(self, multiplier: float = 1.0, max_wait: float = 10.0):
+        self.multiplier = multiplier
+        self.max_wait = max_wait
+    
+    def __call__(self, retry_state: RetryCallState) -> float:
+        cap = self.max_wait
+        if retry_state.args:
+            cap = getattr(retry_state.args[0], "retry_max_backoff", cap)
+        
+        backoff = min(cap, self.multiplier * 2 ** (retry_state.attempt_number - 1))
+        return random.uniform(0, backoff)
+
+
+def build_response_format(
+    mode: ResponseFormat,
+    schema: type[BaseModel] | None = None,
+) -> dict[str, Any] | None:
+    """构建 OpenAI 兼容接口的 response_format 参数
+    
+    Args:
+        mode: 响应格式
+        schema: json_schema 模式下约束输出的 Pydantic 模型
+        
+    Returns:
+        dict | None: text 模式返回 None，不传该参数
+    """
+    if mode == "json_object":
+        return {"type": "json_object"}
+    if mode == "json_schema" and schema is not None:
+        return {
+            "type": "json_schema",
+            "json_schema": {
+                "name": schema.__name__,
+                "schema": schema.model_json_schema(),
+            },
+        }
+    return None
+
+
+# 结构化输出解析失败后的纠正提示
+STRUCTURED_RETRY_PROMPT = """上一次的响应无法解析为合法 JSON ({error})。
+请只返回符合 schema 的合法 JSON，不要包含代码块标记、解释或其他任何文字。"""
+
+_CODE_FENCE_PATTERN = re.compile(r"```(?:json)?\s*(.*?)```", re.DOTALL)
+
+
+def _iter_json_candidates(content: str) -> Iterator[Any]:
+    """按出现顺序依次产出响应文本中可解析的 JSON 值
+    
+    依次处理:
+    1. markdown 代码块 (```json ... ```)
+    2. JSON 前后夹杂的说明文字，从每个 { 或 [ 处尝试解析
+    
+    已解析片段内部的括号不再单独产出，避免返回嵌套的子对象。
+    """
+    text = content.strip()
+    
+    fence = _CODE_FENCE_PATTERN.search(text)
+    if fence:
+        text = fence.group(1).strip()
+    
+    try:
+        yield json.loads(text)
+        return
+    except json.JSONDecodeError:
+        pass
+    
+    decoder = json.JSONDecoder()
+    end = 0
+    for match in re.finditer(r"[\{\[]", text):
+        if match.start() < end:
+            continue
+        try:
+            data, end = decoder.raw_decode(text, match.start())
+        except json.JSONDecodeError:
+            continue
+        yield data
+
+
+def extract_json(
+    content: str,
+    accept: Callable[[Any], bool] | None = None,
+) -> Any:
+    """从 LLM 响应文本中提取第一个 JSON 对象或数组
+    
+    Args:
+        content: LLM 原始响应文本
+        accept: 候选过滤条件，用于跳过 "文献[1]" 之类的引用片段
+        
+    Returns:
+        Any: 解析后的 JSON 数据
+        
+    Raises:
+        LLMResponseError: 未找到可解析 (且满足 accept) 的 JSON
+    """
+    for data in _iter_json_candidates(content):
+        if accept is None or accept(data):
+            return data
+    
+    raise LLMResponseError(f"No valid JSON found in response: {content[:200]!r}")
+
+
+def parse_structured(content: str, schema: type[T]) -> T:
+    """将 LLM 响应解析并校验为 Pydantic 模型
+    
+    依次尝试文本中每个可解析的 JSON，返回第一个通过校验的结果，
+    因此 JSON 前出现的 "文献[1]" 之类的引用不会导致解析失败。
+    
+    Args:
+        content: LLM 原始响应文本
+        schema: Pydantic 模型类，缺失必填字段时校验失败
+        
+    Returns:
+        T: 符合 schema 定义的结构化对象
+        
+    Raises:
+        LLMResponseError: JSON 提取或字段校验失败
+    """
+    first_error: ValidationError | None = None
+    for data in _iter_json_candidates(content):
+        try:
+            return schema.model_validate(data)
+        except ValidationError as e:
+            first_error = first_error or e
+    
+    if first_error is None:
+        raise LLMResponseError(f"No valid JSON found in response: {content[:200]!r}")
+    raise LLMResponseError(f"Structured output validation failed: {first_error}")
@@ -88,6 +88,9 @@ class LLMFactory:
             base_url=config.base_url,
             temperature=config.temperature,
             max_tokens=config.max_tokens,
+            response_format=config.response_format,
+            retry_max_backoff=config.retry_max_backoff,
+            max_retry_after=config.max_retry_after,
         )
     
     @classmethod
@@ -133,6 +136,28 @@ def get_llm(llm_type: LLMType = "basic") -> BaseLLM:
     return _llm_cache[llm_type]
 
 
+def get_agent_params(agent: str, **defaults: Any) -> dict[str, Any]:
+    """获取 Agent 级别的生成参数
+    
+    以代码中的默认值为基础，叠加配置文件 AGENTS 段中该 Agent 的覆盖值。
+    未设置的参数不会返回，由 LLM 实例使用模型级别的默认值。
+    
+    Args:
+        agent: Agent 名称 (coordinator/extractor/analyzer/reporter)
+        **defaults: 代码中的默认参数
+        
+    Returns:
+        dict[str, Any]: 可直接传给 generate/structured_output 的参数
+    """
+    params = dict(defaults)
+    
+    config = get_settings().agents.get(agent)
+    if config is not None:
+        params.update(config.model_dump(exclude_none=True))
+    
+    return params
+
+
 def clear_llm_cache():
     """清除 LLM 缓存"""
     _llm_cache.clear()
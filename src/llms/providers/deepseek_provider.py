@@ -10,7 +10,7 @@ from typing import Any, AsyncIterator, TypeVar
 
 import httpx
 from pydantic import BaseModel
-from tenacity import retry, stop_after_attempt, wait_exponential
+from tenacity import retry, retry_if_exception, stop_after_attempt
 
 from ..base import (
     BaseLLM,
@@ -18,6 +18,10 @@ from ..base import (
     LLMRateLimitError,
     LLMResponse,
     LLMResponseError,
+    is_retryable_error,
+    parse_retry_after,
+    wait_full_jitter,
+    wait_retry_after,
 )
 
 T = TypeVar("T", bound=BaseModel)
@@ -70,9 +74,16 @@ class DeepSeekLLM(BaseLLM):
         messages.append({"role": "user", "content": prompt})
         return messages
     
+    def _clean_structured_content(self, content: str) -> str:
+        """移除推理过程，只保留结论部分"""
+        if "【结论】" in content:
+            return content.split("【结论】")[1]
+        return content
+    
     @retry(
+        retry=retry_if_exception(is_retryable_error),
         stop=stop_after_attempt(3),
-        wait=wait_exponential(multiplier=1, min=2, max=10),
+        wait=wait_retry_after(wait_full_jitter()),
         reraise=True,
     )
     async def generate(
@@ -90,11 +101,15 @@ class DeepSeekLLM(BaseLLM):
                     "messages": self._build_messages(prompt, system_prompt),
                     "temperature": kwargs.get("temperature", self.temperature),
                     "max_tokens": kwargs.get("max_tokens", self.max_tokens),
+                    **self._optional_params(kwargs),
                 },
             )
             
             if response.status_code == 429:
-                raise LLMRateLimitError("Rate limit exceeded")
+                raise LLMRateLimitError(
+                    "Rate limit exceeded",
+                    retry_after=parse_retry_after(response.headers.get("Retry-After")),
+                )
             
             response.raise_for_status()
             data = response.json()
@@ -116,7 +131,11 @@ class DeepSeekLLM(BaseLLM):
         except httpx.ConnectError as e:
             raise LLMConnectionError(f"Failed to connect to DeepSeek: {e}")
         except httpx.HTTPStatusError as e:
-            raise LLMResponseError(f"HTTP error: {e}")
+            raise LLMResponseError(
+                f"HTTP error: {e}",
+                status_code=e.response.status_code,
+                body=e.response.text,
+            )
     
     async def generate_stream(
         self,
@@ -134,6 +153,7 @@ class DeepSeekLLM(BaseLLM):
                     "messages": self._build_messages(prompt, system_prompt),
                     "temperature": kwargs.get("temperature", self.temperature),
                     "max_tokens": kwargs.get("max_tokens", self.max_tokens),
+                    **self._optional_params(kwargs),
                     "stream": True,
                 },
             ) as response:
@@ -172,28 +192,12 @@ class DeepSeekLLM(BaseLLM):
 
 {system_prompt or ''}"""
         
-        response = await self.generate(
+        return await self._generate_structured(
             prompt=prompt,
+            schema=schema,
             system_prompt=enhanced_system,
-            temperature=0,
             **kwargs
         )
-        
-        try:
-            content = response.content
-            # 移除推理过程，只保留结论部分
-            if "【结论】" in content:
-                content = content.split("【结论】")[1]
-            
-            if "```json" in content:
-                content = content.split("```json")[1].split("```")[0]
-            elif "```" in content:
-                content = content.split("```")[1].split("```")[0]
-            
-            data = json.loads(content.strip())
-            return schema.model_validate(data)
-        except (json.JSONDecodeError, ValueError) as e:
-            raise LLMResponseError(f"Failed to parse structured output: {e}")
     
     async def embed(self, text: str | list[str]) -> list[list[float]]:
         """生成文本嵌入向量
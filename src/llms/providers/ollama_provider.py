@@ -12,7 +12,7 @@ from typing import Any, AsyncIterator, TypeVar
 
 import httpx
 from pydantic import BaseModel
-from tenacity import retry, stop_after_attempt, wait_exponential
+from tenacity import retry, retry_if_exception, stop_after_attempt
 
 from ..base import (
     BaseLLM,
@@ -20,6 +20,8 @@ from ..base import (
     LLMRateLimitError,
     LLMResponse,
     LLMResponseError,
+    is_retryable_error,
+    wait_full_jitter,
 )
 
 T = TypeVar("T", bound=BaseModel)
@@ -47,6 +49,11 @@ class OllamaLLM(BaseLLM):
         )
         self._client: httpx.AsyncClient | None = None
     
+    # Ollama 的 JSON 模式通过顶层 format 参数指定，不放入 options
+    OPTIONAL_PARAMS = ("top_p",)
+    # 带引号匹配字段名，避免 "invalid format" 之类的无关错误触发回退
+    RESPONSE_FORMAT_MARKER = '"format"'
+    
     @property
     def client(self) -> httpx.AsyncClient:
         if self._client is None:
@@ -57,9 +64,23 @@ class OllamaLLM(BaseLLM):
             )
         return self._client
     
+    @staticmethod
+    def _format_param(kwargs: dict[str, Any]) -> dict[str, Any]:
+        """将 response_format 映射为 Ollama 的 format 参数
+        
+        json_schema 模式直接传入 schema，其余 JSON 模式使用 "json"。
+        """
+        response_format = kwargs.get("response_format")
+        if not response_format:
+            return {}
+        if response_format.get("type") == "json_schema":
+            return {"format": response_format["json_schema"]["schema"]}
+        return {"format": "json"}
+    
     @retry(
+        retry=retry_if_exception(is_retryable_error),
         stop=stop_after_attempt(3),
-        wait=wait_exponential(multiplier=1, min=2, max=10),
+        wait=wait_full_jitter(),
         reraise=True,
     )
     async def generate(
@@ -82,7 +103,9 @@ class OllamaLLM(BaseLLM):
                     "options": {
                         "temperature": kwargs.get("temperature", self.temperature),
                         "num_predict": kwargs.get("max_tokens", self.max_tokens),
+                        **self._optional_params(kwargs),
                     },
+                    **self._format_param(kwargs),
                     "stream": False,
                 },
             )
@@ -104,7 +127,11 @@ class OllamaLLM(BaseLLM):
         except httpx.ConnectError as e:
             raise LLMConnectionError(f"Failed to connect to Ollama: {e}. Is Ollama running?")
         except httpx.HTTPStatusError as e:
-            raise LLMResponseError(f"HTTP error: {e}")
+            raise LLMResponseError(
+                f"HTTP error: {e}",
+                status_code=e.response.status_code,
+                body=e.response.text,
+            )
     
     async def generate_stream(
         self,
@@ -127,7 +154,9 @@ class OllamaLLM(BaseLLM):
                     "options": {
                         "temperature": kwargs.get("temperature", self.temperature),
                         "num_predict": kwargs.get("max_tokens", self.max_tokens),
+                        **self._optional_params(kwargs),
                     },
+                    **self._format_param(kwargs),
                     "stream": True,
                 },
             ) as response:
@@ -161,24 +190,12 @@ class OllamaLLM(BaseLLM):
 
 {system_prompt or ''}"""
         
-        response = await self.generate(
+        return await self._generate_structured(
             prompt=prompt,
+            schema=schema,
             system_prompt=enhanced_system,
-            temperature=0,
             **kwargs
         )
-        
-        try:
-            content = response.content
-            if "```json" in content:
-                content = content.split("```json")[1].split("```")[0]
-            elif "```" in content:
-                content = content.split("```")[1].split("```")[0]
-            
-            data = json.loads(content.strip())
-            return schema.model_validate(data)
-        except (json.JSONDecodeError, ValueError) as e:
-            raise LLMResponseError(f"Failed to parse structured output: {e}")
     
     async def embed(self, text: str | list[str]) -> list[list[float]]:
         """生成文本嵌入向量"""
@@ -11,7 +11,7 @@ from typing import Any, AsyncIterator, TypeVar
 
 import httpx
 from pydantic import BaseModel
-from tenacity import retry, stop_after_attempt, wait_exponential
+from tenacity import retry, retry_if_exception, stop_after_attempt
 
 from ..base import (
     BaseLLM,
@@ -19,6 +19,10 @@ from ..base import (
     LLMRateLimitError,
     LLMResponse,
     LLMResponseError,
+    is_retryable_error,
+    parse_retry_after,
+    wait_full_jitter,
+    wait_retry_after,
 )
 
 T = TypeVar("T", bound=BaseModel)
@@ -72,8 +76,9 @@ class OpenAILLM(BaseLLM):
         return messages
     
     @retry(
+        retry=retry_if_exception(is_retryable_error),
         stop=stop_after_attempt(3),
-        wait=wait_exponential(multiplier=1, min=2, max=10),
+        wait=wait_retry_after(wait_full_jitter()),
         reraise=True,
     )
     async def generate(
@@ -91,11 +96,15 @@ class OpenAILLM(BaseLLM):
                     "messages": self._build_messages(prompt, system_prompt),
                     "temperature": kwargs.get("temperature", self.temperature),
                     "max_tokens": kwargs.get("max_tokens", self.max_tokens),
+                    **self._optional_params(kwargs),
                 },
             )
             
             if response.status_code == 429:
-                raise LLMRateLimitError("Rate limit exceeded")
+                raise LLMRateLimitError(
+                    "Rate limit exceeded",
+                    retry_after=parse_retry_after(response.headers.get("Retry-After")),
+                )
             
             response.raise_for_status()
             data = response.json()
@@ -110,7 +119,11 @@ class OpenAILLM(BaseLLM):
         except httpx.ConnectError as e:
             raise LLMConnectionError(f"Failed to connect to OpenAI: {e}")
         except httpx.HTTPStatusError as e:
-            raise LLMResponseError(f"HTTP error: {e}")
+            raise LLMResponseError(
+                f"HTTP error: {e}",
+                status_code=e.response.status_code,
+                body=e.response.text,
+            )
     
     async def generate_stream(
         self,
@@ -128,6 +141,7 @@ class OpenAILLM(BaseLLM):
                     "messages": self._build_messages(prompt, system_prompt),
                     "temperature": kwargs.get("temperature", self.temperature),
                     "max_tokens": kwargs.get("max_tokens", self.max_tokens),
+                    **self._optional_params(kwargs),
                     "stream": True,
                 },
             ) as response:
@@ -162,26 +176,12 @@ class OpenAILLM(BaseLLM):
 
 {system_prompt or ''}"""
         
-        response = await self.generate(
+        return await self._generate_structured(
             prompt=prompt,
+            schema=schema,
             system_prompt=enhanced_system,
-            temperature=0,  # 结构化输出使用低温度
             **kwargs
         )
-        
-        # 解析 JSON 响应
-        try:
-            # 尝试提取 JSON 内容
-            content = response.content
-            if "```json" in content:
-                content = content.split("```json")[1].split("```")[0]
-            elif "```" in content:
-                content = content.split("```")[1].split("```")[0]
-            
-            data = json.loads(content.strip())
-            return schema.model_validate(data)
-        except (json.JSONDecodeError, ValueError) as e:
-            raise LLMResponseError(f"Failed to parse structured output: {e}")
     
     async def embed(self, text: str | list[str]) -> list[list[float]]:
         """生成文本嵌入向量"""
@@ -0,0 +1,106 @@
+# LLM 工厂测试
+"""
+测试 Agent 级别生成参数的加载、合并与传递
+"""
+
+from types import SimpleNamespace
+
+import pytest
+
+from src.config.settings import AgentLLMConfig, Settings
+from src.graph.nodes import reporter
+from src.llms import factory
+from src.llms.base import LLMResponse
+from src.llms.factory import get_agent_params
+
+
+@pytest.fixture(autouse=True)
+def agent_settings(monkeypatch):
+    """使用固定的 Agent 配置替换全局配置"""
+    settings = SimpleNamespace(agents={
+        "extractor": AgentLLMConfig(temperature=0.0),
+        "reporter": AgentLLMConfig(temperature=0.7, max_tokens=8192),
+    })
+    monkeypatch.setattr(factory, "get_settings", lambda: settings)
+
+
+class TestGetAgentParams:
+    """Agent 生成参数测试"""
+    
+    def test_low_temperature_for_extractor(self):
+        """测试抽取 Agent 使用配置中的低温度"""
+        assert get_agent_params("extractor", temperature=0.3) == {"temperature": 0.0}
+    
+    def test_reporter_overrides(self):
+        """测试报告 Agent 覆盖温度与最大 token 数"""
+        assert get_agent_params("reporter") == {"temperature": 0.7, "max_tokens": 8192}
+    
+    def test_unset_fields_are_omitted(self):
+        """测试未配置的字段不会覆盖代码默认值"""
+        assert get_agent_params("reporter", top_p=0.9)["top_p"] == 0.9
+    
+    def test_unknown_agent_uses_defaults(self):
+        """测试未配置的 Agent 使用代码默认值"""
+        assert get_agent_params("analyzer") == {}
+        assert get_agent_params("coordinator", temperature=0) == {"temperature": 0}
+
+
+class TestAgentSettings:
+    """AGENTS 配置加载测试"""
+    
+    def test_from_yaml(self, tmp_path, monkeypatch):
+        """测试从 YAML 加载 Agent 参数，且不受环境变量影响"""
+        monkeypatch.setenv("TEMPERATURE", "0.9")
+        monkeypatch.setenv("MAX_TOKENS", "100")
+        config_path = tmp_path / "conf.yaml"
+        config_path.write_text(
+            "AGENTS:\n"
+            "  extractor:\n"
+            "    temperature: 0.0\n"
+            "  reporter:\n"
+            "    temperature: 0.7\n"
+            "    max_tokens: 8192\n"
+            "  analyzer:\n",
+            encoding="utf-8",
+        )
+        
+        agents = Settings.from_yaml(config_path).agents
+        
+        assert agents["extractor"].temperature == 0.0
+        assert agents["extractor"].max_tokens is None
+        assert agents["reporter"].max_tokens == 8192
+        assert agents["analyzer"].model_dump(exclude_none=True) == {}
+
+
+class StubLLM:
+    """记录调用参数的 LLM 桩"""
+    
+    def __init__(self):
+        self.calls: list[dict] = []
+    
+    async def generate(self, prompt: str, system_prompt: str | None = None, **kwargs) -> LLMResponse:
+        self.calls.append(kwargs)
+        return LLMResponse(content="报告", model="stub")
+
+
+class TestNodeParams:
+    """节点传递 Agent 参数测试"""
+    
+    async def test_reporter_forwards_params(self, monkeypatch):
+        """测试报告节点以配置的温度与最大 token 数调用 LLM"""
+        llm = StubLLM()
+        monkeypatch.setattr(reporter, "get_llm", lambda llm_type: llm)
+        state = SimpleNamespace(
+            user_query="评估 A 公司",
+            analysis_results=[],
+            completed_tasks=[],
+            extracted_entities=[],
+            created_nodes=[],
+            graph_query_results=[],
+        )
+        
+        result = await reporter.reporter_node(state)
+        
+        assert result["final_report"] == "报告"
+        assert llm.calls
+        assert all(call == {"temperature": 0.7, "max_tokens": 8192} for call in llm.calls)
@@ -0,0 +1,203 @@
+# LLM JSON 模式测试
+"""
+测试结构化输出的 response_format 传递、不支持时的回退与解析失败后的纠正重试
+"""
+
+import json
+
+import httpx
+import pytest
+from pydantic import BaseModel
+
+from src.llms.base import LLMResponseError, STRUCTURED_RETRY_PROMPT, build_response_format
+from src.llms.providers import OllamaLLM, OpenAILLM
+
+
+class SampleOutput(BaseModel):
+    """测试用结构化输出"""
+    name: str
+    score: float
+
+
+def _chat_completion(content: str) -> dict:
+    """构造 OpenAI 兼容接口的响应体"""
+    return {
+        "model": "gpt-4o-mini",
+        "choices": [{"message": {"content": content}}],
+        "usage": {"prompt_tokens": 1, "completion_tokens": 1, "total_tokens": 2},
+    }
+
+
+class TestBuildResponseFormat:
+    """response_format 构建测试"""
+    
+    def test_text(self):
+        """测试 text 模式不传参数"""
+        assert build_response_format("text", SampleOutput) is None
+    
+    def test_json_object(self):
+        """测试 json_object 模式"""
+        assert build_response_format("json_object", SampleOutput) == {"type": "json_object"}
+    
+    def test_json_schema_carries_schema(self):
+        """测试 json_schema 模式携带 schema"""
+        response_format = build_response_format("json_schema", SampleOutput)
+        
+        assert response_format["type"] == "json_schema"
+        assert response_format["json_schema"]["name"] == "SampleOutput"
+        assert response_format["json_schema"]["schema"] == SampleOutput.model_json_schema()
+
+
+def _openai_llm(replies: list[str], requests: list[dict]) -> OpenAILLM:
+    """按顺序返回 replies 的 OpenAI LLM，请求体记录到 requests"""
+    contents = iter(replies)
+    
+    def handler(request: httpx.Request) -> httpx.Response:
+        requests.append(json.loads(request.content))
+        return httpx.Response(200, json=_chat_completion(next(contents)))
+    
+    llm = OpenAILLM(api_key="test")
+    llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+    return llm
+
+
+class TestStructuredOutputRetry:
+    """结构化输出纠正重试测试"""
+    
+    async def test_corrective_retry(self):
+        """测试首次输出无法解析时以纠正提示重试一次"""
+        requests = []
+        llm = _openai_llm(["抱歉，我无法给出结果。", '{"name": "A", "score": 1}'], requests)
+        
+        result = await llm.structured_output("分析", SampleOutput)
+        
+        assert result.name == "A"
+        assert len(requests) == 2
+        corrective = STRUCTURED_RETRY_PROMPT.splitlines()[-1]
+        assert corrective not in requests[0]["messages"][-1]["content"]
+        assert corrective in requests[1]["messages"][-1]["content"]
+    
+    async def test_gives_up_after_retry(self):
+        """测试纠正重试后仍无法解析时抛出错误"""
+        requests = []
+        llm = _openai_llm(["抱歉。", '{"name": "A"}'], requests)
+        
+        with pytest.raises(LLMResponseError):
+            await llm.structured_output("分析", SampleOutput)
+        
+        assert len(requests) == 2
+
+
+class TestStructuredOutputJsonMode:
+    """结构化输出 JSON 模式测试"""
+    
+    async def test_response_format_transmitted(self):
+        """测试请求中携带 response_format"""
+        requests = []
+        
+        def handler(request: httpx.Request) -> httpx.Response:
+            requests.append(json.loads(request.content))
+            return httpx.Response(200, json=_chat_completion('{"name": "A", "score": 1}'))
+        
+        llm = OpenAILLM(api_key="test", response_format="json_schema")
+        llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+        
+        result = await llm.structured_output("分析", SampleOutput)
+        
+        assert result.name == "A"
+        assert requests[0]["response_format"]["json_schema"]["name"] == "SampleOutput"
+    
+    async def test_fallback_when_unsupported(self):
+        """测试模型拒绝 response_format 时本次请求退回 text，且不重试 400"""
+        requests = []
+        
+        def handler(request: httpx.Request) -> httpx.Response:
+            body = json.loads(request.content)
+            requests.append(body)
+            if "response_format" in body:
+                return httpx.Response(400, json={
+                    "error": {"message": "'response_format' is not supported with this model."},
+                })
+            return httpx.Response(200, json=_chat_completion('{"name": "A", "score": 1}'))
+        
+        llm = OpenAILLM(api_key="test")
+        llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+        
+        result = await llm.structured_output("分析", SampleOutput)
+        
+        assert result.score == 1
+        assert len(requests) == 2
+        assert "response_format" not in requests[1]
+        assert llm.response_format == "json_object"
+    
+    async def test_unrelated_400_not_fallback(self):
+        """测试与 JSON 模式无关的 400 直接抛出，不重试也不改变配置"""
+        requests = []
+        
+        def handler(request: httpx.Request) -> httpx.Response:
+            requests.append(json.loads(request.content))
+            return httpx.Response(400, json={
+                "error": {"message": "This model's maximum context length is 8192 tokens."},
+            })
+        
+        llm = OpenAILLM(api_key="test")
+        llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+        
+        with pytest.raises(LLMResponseError) as exc_info:
+            await llm.structured_output("分析", SampleOutput)
+        
+        assert exc_info.value.status_code == 400
+        assert len(requests) == 1
+        assert llm.response_format == "json_object"
+    
+    async def test_ollama_format(self):
+        """测试 Ollama 使用顶层 format 参数"""
+        requests = []
+        
+        def handler(request: httpx.Request) -> httpx.Response:
+            requests.append(json.loads(request.content))
+            return httpx.Response(200, json={"response": '{"name": "A", "score": 1}'})
+        
+        llm = OllamaLLM()
+        llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+        
+        await llm.structured_output("分析", SampleOutput)
+        
+        assert requests[0]["format"] == "json"
+        assert "response_format" not in requests[0]["options"]
+    
+    async def test_ollama_unrelated_format_error(self):
+        """测试 Ollama 中与 format 参数无关的 400 不触发回退"""
+        requests = []
+        
+        def handler(request: httpx.Request) -> httpx.Response:
+            requests.append(json.loads(request.content))
+            return httpx.Response(400, json={"error": "unsupported image format"})
+        
+        llm = OllamaLLM()
+        llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+        
+        with pytest.raises(LLMResponseError):
+            await llm.structured_output("分析", SampleOutput)
+        
+        assert len(requests) == 1
+    
+    async def test_ollama_format_rejected(self):
+        """测试 Ollama 拒绝 "format" 参数时本次请求退回 text"""
+        requests = []
+        
+        def handler(request: httpx.Request) -> httpx.Response:
+            body = json.loads(request.content)
+            requests.append(body)
+            if "format" in body:
+                return httpx.Response(400, json={"error": 'invalid value for "format"'})
+            return httpx.Response(200, json={"response": '{"name": "A", "score": 1}'})
+        
+        llm = OllamaLLM()
+        llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+        
+        result = await llm.structured_output("分析", SampleOutput)
+        
+        assert result.name == "A"
+        assert len(requests) == 2
+        assert "format" not in requests[1]
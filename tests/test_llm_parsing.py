@@ -0,0 +1,123 @@
+# LLM 结构化输出解析测试
+"""
+测试 LLM 响应中 JSON 的提取与校验
+"""
+
+from types import SimpleNamespace
+
+import pytest
+from pydantic import BaseModel
+
+from src.graph.nodes import extractor
+from src.graph.state import Task, TaskType
+from src.llms import factory
+from src.llms.base import LLMResponse, LLMResponseError, extract_json, parse_structured
+
+
+class SampleOutput(BaseModel):
+    """测试用结构化输出"""
+    name: str
+    score: float
+
+
+class TestExtractJson:
+    """JSON 提取测试"""
+    
+    def test_plain_json(self):
+        """测试纯 JSON"""
+        assert extract_json('{"name": "A", "score": 1}') == {"name": "A", "score": 1}
+    
+    def test_fenced_json(self):
+        """测试 markdown 代码块包裹的 JSON"""
+        content = '```json\n{"name": "A", "score": 1}\n```'
+        assert extract_json(content) == {"name": "A", "score": 1}
+    
+    def test_fenced_without_language(self):
+        """测试未标注语言的代码块"""
+        content = '```\n[1, 2, 3]\n```'
+        assert extract_json(content) == [1, 2, 3]
+    
+    def test_leading_prose(self):
+        """测试 JSON 前后夹杂说明文字"""
+        content = '以下是分析结果:\n{"name": "A", "score": 0.5}\n希望对你有帮助。'
+        assert extract_json(content) == {"name": "A", "score": 0.5}
+    
+    def test_accept_skips_citation(self):
+        """测试 accept 条件跳过不符合要求的候选"""
+        content = '根据文献[1]，结果: [{"name": "A"}]'
+        
+        assert extract_json(content) == [1]
+        assert extract_json(content, accept=lambda data: data != [1]) == [{"name": "A"}]
+    
+    def test_truncated_output(self):
+        """测试被截断的输出"""
+        with pytest.raises(LLMResponseError):
+            extract_json('{"name": "A", "score": ')
+    
+    def test_no_json(self):
+        """测试不包含 JSON 的输出"""
+        with pytest.raises(LLMResponseError):
+            extract_json("抱歉，我无法完成该任务。")
+
+
+class TestParseStructured:
+    """结构化解析测试"""
+    
+    def test_parse_valid(self):
+        """测试合法输出"""
+        result = parse_structured('结果: {"name": "A", "score": 0.9}', SampleOutput)
+        
+        assert result.name == "A"
+        assert result.score == 0.9
+    
+    def test_citation_before_object(self):
+        """测试 JSON 前的文献引用 [1] 不影响解析"""
+        content = '根据文献[1]，分析结果: {"name": "A", "score": 0.9}'
+        
+        result = parse_structured(content, SampleOutput)
+        
+        assert result.name == "A"
+    
+    def test_missing_required_field(self):
+        """测试缺失必填字段"""
+        with pytest.raises(LLMResponseError):
+            parse_structured('{"name": "A"}', SampleOutput)
+
+
+class StubLLM:
+    """返回固定内容的 LLM 桩"""
+    
+    def __init__(self, content: str):
+        self.content = content
+    
+    async def generate(self, prompt: str, system_prompt: str | None = None, **kwargs) -> LLMResponse:
+        return LLMResponse(content=self.content, model="stub")
+
+
+class TestExtractorParsing:
+    """提取节点解析测试"""
+    
+    async def test_citation_before_array(self, monkeypatch):
+        """测试数组前的文献引用不会导致实体被丢弃"""
+        content = '根据文献[1]，结果: [{"name": "药物A", "molecule_type": "ADC", "confidence": 0.9}]'
+        monkeypatch.setattr(extractor, "get_llm", lambda llm_type: StubLLM(content))
+        monkeypatch.setattr(factory, "get_settings", lambda: SimpleNamespace(agents={}))
+        task = Task(
+            id="t1",
+            type=TaskType.EXTRACT_DATA,
+            description="提取药物",
+            parameters={"text": "药物A 是一款 ADC", "target_entities": ["Drug"]},
+        )
+        state = SimpleNamespace(
+            current_task=task,
+            user_query="",
+            completed_tasks=[],
+            extracted_entities=[],
+        )
+        
+        result = await extractor.extractor_node(state)
+        
+        entities = result["extracted_entities"]
+        assert len(entities) == 1
+        assert entities[0].data == {"name": "药物A", "molecule_type": "ADC"}
+        assert entities[0].confidence == 0.9
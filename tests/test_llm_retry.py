@@ -0,0 +1,192 @@
+# LLM 重试策略测试
+"""
+测试速率限制时的 Retry-After 解析与等待策略，以及全抖动退避
+"""
+
+import asyncio
+from datetime import datetime, timedelta, timezone
+from email.utils import format_datetime
+from types import SimpleNamespace
+
+import httpx
+import pytest
+from tenacity import wait_fixed
+
+from src.llms.base import (
+    LLMRateLimitError,
+    LLMResponseError,
+    is_retryable_error,
+    parse_retry_after,
+    wait_full_jitter,
+    wait_retry_after,
+)
+from src.llms.providers import OpenAILLM
+
+
+def _retry_state(exc: Exception, *args) -> SimpleNamespace:
+    """构造只包含上一次失败结果的重试状态"""
+    return SimpleNamespace(outcome=SimpleNamespace(exception=lambda: exc), args=args)
+
+
+def _attempt_state(attempt_number: int, *args) -> SimpleNamespace:
+    """构造第 attempt_number 次失败后的重试状态"""
+    return SimpleNamespace(attempt_number=attempt_number, args=args)
+
+
+class TestParseRetryAfter:
+    """Retry-After 头解析测试"""
+    
+    def test_seconds(self):
+        """测试秒数格式"""
+        assert parse_retry_after("7") == 7.0
+        assert parse_retry_after("1.5") == 1.5
+    
+    def test_http_date(self):
+        """测试 HTTP 日期格式"""
+        retry_at = datetime.now(timezone.utc) + timedelta(seconds=30)
+        
+        seconds = parse_retry_after(format_datetime(retry_at, usegmt=True))
+        
+        assert 25 <= seconds <= 30
+    
+    def test_past_date(self):
+        """测试已过期的日期"""
+        retry_at = datetime.now(timezone.utc) - timedelta(minutes=5)
+        
+        assert parse_retry_after(format_datetime(retry_at, usegmt=True)) == 0.0
+    
+    def test_missing_or_invalid(self):
+        """测试缺失或非法的值"""
+        assert parse_retry_after(None) is None
+        assert parse_retry_after("") is None
+        assert parse_retry_after("soon") is None
+
+
+class TestWaitRetryAfter:
+    """Retry-After 等待策略测试"""
+    
+    def test_uses_server_hint(self):
+        """测试遵循服务端指定的等待时长"""
+        wait = wait_retry_after(wait_fixed(5))
+        
+        assert wait(_retry_state(LLMRateLimitError(retry_after=12))) == 12
+    
+    def test_caps_server_hint(self):
+        """测试服务端等待时长不超过上限"""
+        wait = wait_retry_after(wait_fixed(5), max_wait=20)
+        
+        assert wait(_retry_state(LLMRateLimitError(retry_after=300))) == 20
+    
+    def test_cap_from_instance(self):
+        """测试上限取自 LLM 实例配置"""
+        wait = wait_retry_after(wait_fixed(5), max_wait=60)
+        llm = SimpleNamespace(max_retry_after=8)
+        
+        assert wait(_retry_state(LLMRateLimitError(retry_after=30), llm)) == 8
+    
+    def test_fallback_without_hint(self):
+        """测试无 Retry-After 时退回默认策略"""
+        wait = wait_retry_after(wait_fixed(5))
+        
+        assert wait(_retry_state(LLMRateLimitError())) == 5
+        assert wait(_retry_state(LLMResponseError("bad"))) == 5
+
+
+class TestProviderRetryAfter:
+    """客户端遵循 Retry-After 测试"""
+    
+    async def _generate_after_429(self, monkeypatch, retry_after: str, **llm_kwargs) -> list[float]:
+        """首次请求返回 429，再次请求成功，返回重试前的等待时长"""
+        responses = iter([
+            httpx.Response(429, headers={"Retry-After": retry_after}),
+            httpx.Response(200, json={
+                "model": "gpt-4o-mini",
+                "choices": [{"message": {"content": "ok"}}],
+            }),
+        ])
+        sleeps = []
+        
+        async def fake_sleep(seconds: float) -> None:
+            sleeps.append(seconds)
+        
+        monkeypatch.setattr(OpenAILLM.generate.retry, "sleep", fake_sleep)
+        
+        llm = OpenAILLM(api_key="test", **llm_kwargs)
+        llm._client = httpx.AsyncClient(
+            base_url=llm.base_url,
+            transport=httpx.MockTransport(lambda request: next(responses)),
+        )
+        
+        response = await llm.generate(prompt="hi")
+        
+        assert response.content == "ok"
+        return sleeps
+    
+    async def test_waits_server_hint(self, monkeypatch):
+        """测试 429 后按 Retry-After 等待再重试"""
+        assert await self._generate_after_429(monkeypatch, "3") == [3]
+    
+    async def test_configured_cap(self, monkeypatch):
+        """测试等待时长不超过配置的 max_retry_after"""
+        assert await self._generate_after_429(monkeypatch, "300", max_retry_after=5) == [5]
+
+
+class TestRetryPredicate:
+    """重试判定测试"""
+    
+    def test_classification(self):
+        """测试各类错误是否重试"""
+        assert is_retryable_error(LLMRateLimitError())
+        assert is_retryable_error(LLMResponseError("server", status_code=503))
+        assert not is_retryable_error(LLMResponseError("bad request", status_code=400))
+        assert not is_retryable_error(asyncio.CancelledError())
+    
+    async def test_cancellation_not_retried(self):
+        """测试请求被取消时立即抛出，不再重试"""
+        requests = []
+        
+        def handler(request: httpx.Request) -> httpx.Response:
+            requests.append(request)
+            raise asyncio.CancelledError()
+        
+        llm = OpenAILLM(api_key="test")
+        llm._client = httpx.AsyncClient(base_url=llm.base_url, transport=httpx.MockTransport(handler))
+        
+        with pytest.raises(asyncio.CancelledError):
+            await llm.generate(prompt="hi")
+        
+        assert len(requests) == 1
+
+
+class TestWaitFullJitter:
+    """全抖动退避测试"""
+    
+    def test_within_exponential_bounds(self):
+        """测试每次等待落在 [0, 2^(n-1)] 内"""
+        wait = wait_full_jitter(max_wait=1000)
+        
+        for attempt in range(1, 8):
+            upper = 2 ** (attempt - 1)
+            for _ in range(50):
+                assert 0 <= wait(_attempt_state(attempt)) <= upper
+    
+    def test_respects_cap(self):
+        """测试等待不超过上限"""
+        wait = wait_full_jitter(max_wait=10)
+        
+        samples = [wait(_attempt_state(attempt)) for attempt in range(1, 20) for _ in range(20)]
+        
+        assert max(samples) <= 10
+    
+    def test_cap_from_instance(self):
+        """测试上限取自 LLM 实例配置"""
+        wait = wait_full_jitter(max_wait=10)
+        llm = SimpleNamespace(retry_max_backoff=3)
+        
+        assert all(wait(_attempt_state(10, llm)) <= 3 for _ in range(100))
+    
+    def test_jittered(self):
+        """测试同一次重试的等待时长不固定"""
+        wait = wait_full_jitter(max_wait=60)
+        
+        assert len({wait(_attempt_state(6)) for _ in range(20)}) > 1
@@ -0,0 +1,145 @@
+# 向量数据库客户端测试
+"""
+测试向量存储接口约定、客户端创建与 Chroma 实现的写入、检索与校验
+"""
+
+from types import SimpleNamespace
+from typing import Any
+
+import pytest
+
+from src.knowledge import vector_store
+from src.knowledge.vector_store import (
+    ChromaVectorStore,
+    VectorDimensionError,
+    VectorMatch,
+    VectorPayloadError,
+    VectorStore,
+    get_vector_store,
+)
+
+
+class StubVectorStore(VectorStore):
+    """只做维度校验的向量存储，用于测试基类约定"""
+    
+    async def store(
+        self,
+        id: str,
+        vector: list[float],
+        payload: dict[str, Any] | None = None,
+    ) -> None:
+        self._check_dimension(vector)
+    
+    async def search(
+        self,
+        vector: list[float],
+        top_k: int = 5,
+    ) -> list[VectorMatch]:
+        self._check_dimension(vector)
+        return []
+
+
+def _vector_db_settings(**overrides) -> SimpleNamespace:
+    """构造只包含 vector_db 配置的全局设置"""
+    config = {
+        "type": "chroma",
+        "persist_directory": "./data/test_chroma",
+        "collection_name": "test_docs",
+        "dimension": 3,
+        "metric": "l2",
+        **overrides,
+    }
+    return SimpleNamespace(vector_db=SimpleNamespace(**config))
+
+
+@pytest.fixture(autouse=True)
+def reset_vector_store(monkeypatch):
+    """每个测试使用新的全局客户端实例"""
+    monkeypatch.setattr(vector_store, "_vector_store", None)
+
+
+@pytest.fixture
+def chroma_store(tmp_path) -> ChromaVectorStore:
+    """使用临时目录的 3 维 Chroma 向量存储"""
+    pytest.importorskip("chromadb")
+    return ChromaVectorStore(
+        persist_directory=str(tmp_path),
+        collection_name="test_docs",
+        dimension=3,
+    )
+
+
+class TestVectorStoreContract:
+    """向量存储接口约定测试"""
+    
+    def test_abstract(self):
+        """测试抽象基类不能直接实例化"""
+        with pytest.raises(TypeError):
+            VectorStore(dimension=3)
+    
+    async def test_dimension_mismatch(self):
+        """测试向量维度不一致"""
+        store = StubVectorStore(dimension=3)
+        
+        with pytest.raises(VectorDimensionError):
+            await store.store("a", [1.0, 0.0])
+        
+        with pytest.raises(VectorDimensionError):
+            await store.search([1.0, 0.0, 0.0, 0.0])
+
+
+class TestGetVectorStore:
+    """向量数据库客户端创建测试"""
+    
+    def test_chroma_backend(self, monkeypatch):
+        """测试按配置创建 Chroma 客户端单例"""
+        monkeypatch.setattr(vector_store, "get_settings", lambda: _vector_db_settings())
+        
+        store = get_vector_store()
+        
+        assert isinstance(store, ChromaVectorStore)
+        assert store.collection_name == "test_docs"
+        assert store.dimension == 3
+        assert store.metric == "l2"
+        assert get_vector_store() is store
+    
+    def test_unsupported_backend(self, monkeypatch):
+        """测试尚未支持的后端"""
+        monkeypatch.setattr(vector_store, "get_settings", lambda: _vector_db_settings(type="milvus"))
+        
+        with pytest.raises(NotImplementedError, match="milvus"):
+            get_vector_store()
+
+
+class TestChromaVectorStore:
+    """Chroma 向量存储测试"""
+    
+    async def test_store_and_search(self, chroma_store):
+        """测试写入后按相似度检索"""
+        await chroma_store.store("a", [1.0, 0.0, 0.0], {"source": "trial_abstract"})
+        await chroma_store.store("b", [0.0, 1.0, 0.0], {"source": "prior_report"})
+        
+        matches = await chroma_store.search([0.9, 0.1, 0.0], top_k=2)
+        
+        assert [m.id for m in matches] == ["a", "b"]
+        assert matches[0].payload == {"source": "trial_abstract"}
+        assert matches[0].score > matches[1].score
+    
+    async def test_search_empty_collection(self, chroma_store):
+        """测试空集合检索返回空列表"""
+        assert await chroma_store.search([1.0, 0.0, 0.0]) == []
+    
+    async def test_store_overwrites_same_id(self, chroma_store):
+        """测试相同 id 覆盖写入"""
+        await chroma_store.store("a", [1.0, 0.0, 0.0], {"version": 1})
+        await chroma_store.store("a", [1.0, 0.0, 0.0], {"version": 2})
+        
+        matches = await chroma_store.search([1.0, 0.0, 0.0], top_k=5)
+        
+        assert len(matches) == 1
+        assert matches[0].payload == {"version": 2}
+    
+    async def test_non_scalar_payload(self, chroma_store):
+        """测试嵌套元数据给出明确错误"""
+        with pytest.raises(VectorPayloadError, match="tags"):
+            await chroma_store.store("a", [1.0, 0.0, 0.0], {"tags": ["oncology"]})